@@ -0,0 +1,520 @@
+package irr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/bbgo"
+	"github.com/c9s/bbgo/pkg/datatype/floats"
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+const CrossSectionalID = "irr_cross_sectional"
+
+func init() {
+	bbgo.RegisterStrategy(CrossSectionalID, &CrossSectionalStrategy{})
+}
+
+// CrossSectionalStrategy ranks the NRR alpha of a universe of symbols on
+// every interval close and builds a dollar-neutral long/short portfolio:
+// long the TopK worst-return names, short the TopK best-return names (or the
+// reverse, when Reverse is set).
+type CrossSectionalStrategy struct {
+	Environment *bbgo.Environment
+	Symbols     []string `json:"symbols"`
+
+	types.IntervalWindow
+
+	// TopK is the number of names to long and to short each side of the ranking.
+	TopK int `json:"topK"`
+
+	// Reverse swaps the default ranking direction: long the best-return names
+	// and short the worst-return names instead.
+	Reverse bool `json:"reverse"`
+
+	bbgo.QuantityOrAmount
+
+	// 买入价格比当前价格低的比例
+	BidSpread fixedpoint.Value `json:"bidSpread"`
+
+	// 卖出价格比当前价格高的比例
+	AskSpread fixedpoint.Value `json:"askSpread"`
+
+	// 风险管理参数，应用于整个组合而非单个标的
+	MaxDrawdown       fixedpoint.Value `json:"maxDrawdown"`
+	DailyLossLimit    fixedpoint.Value `json:"dailyLossLimit"`
+	PositionSizeLimit fixedpoint.Value `json:"positionSizeLimit"`
+	RiskTimezone      string           `json:"riskTimezone"`
+
+	MetricsPort int `json:"metricsPort"`
+
+	// whether to draw graph or not by the end of backtest
+	DrawGraph       bool   `json:"drawGraph"`
+	GraphPNLPath    string `json:"graphPNLPath"`
+	GraphCumPNLPath string `json:"graphCumPNLPath"`
+
+	// Positions, ProfitStats and TradeStats are keyed by symbol since the
+	// portfolio trades the whole universe independently per name.
+	Positions   map[string]*types.Position    `persistence:"positions"`
+	ProfitStats map[string]*types.ProfitStats `persistence:"profit_stats"`
+	TradeStats  map[string]*types.TradeStats  `persistence:"trade_stats"`
+
+	// AccumulatedProfitReports is keyed by symbol; outputPortfolioReport
+	// aggregates them across the whole portfolio at shutdown.
+	AccumulatedProfitReports map[string]*AccumulatedProfitReport `json:"accumulatedProfitReports"`
+
+	ExitMethods bbgo.ExitMethodSet `json:"exits"`
+	bbgo.StrategyController
+
+	session        *bbgo.ExchangeSession
+	orderExecutors map[string]*bbgo.GeneralOrderExecutor
+	activeOrders   map[string]*bbgo.ActiveOrderBook
+	nrrs           map[string]*NRR
+	latestClose    map[string]fixedpoint.Value
+
+	markets       map[string]types.Market
+	quoteCurrency string
+
+	riskManager *RiskManager
+	metrics     map[string]*metricsRecorder
+
+	// lastRebalanceBar de-dupes onKLineClosed: every symbol in the universe
+	// closes a kline on the same interval boundary, but a rebalance is a
+	// whole-portfolio operation and must only run once per bar.
+	lastRebalanceBar time.Time
+
+	stopC chan struct{}
+}
+
+func (s *CrossSectionalStrategy) ID() string {
+	return CrossSectionalID
+}
+
+func (s *CrossSectionalStrategy) InstanceID() string {
+	return fmt.Sprintf("%s:%s", CrossSectionalID, strings.Join(s.Symbols, ","))
+}
+
+func (s *CrossSectionalStrategy) Subscribe(session *bbgo.ExchangeSession) {
+	for _, symbol := range s.Symbols {
+		session.Subscribe(types.KLineChannel, symbol, types.SubscribeOptions{Interval: s.Interval})
+	}
+}
+
+func (s *CrossSectionalStrategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, session *bbgo.ExchangeSession) error {
+	var instanceID = s.InstanceID()
+
+	// Quantity is a per-symbol base quantity and isn't comparable across a
+	// universe of symbols with different prices, so it can't stand in for
+	// the shared dollar amount onKLineClosed splits across legs the way it
+	// can for the single-symbol Strategy. Amount is the only sizing mode
+	// that's meaningful here.
+	if s.QuantityOrAmount.Amount.IsZero() {
+		return fmt.Errorf("%s requires QuantityOrAmount.Amount to be set; Quantity is not supported in cross-sectional mode", CrossSectionalID)
+	}
+
+	if s.TopK <= 0 {
+		s.TopK = 1
+	}
+	if s.BidSpread.IsZero() {
+		s.BidSpread = fixedpoint.NewFromFloat(0.0001)
+	}
+	if s.AskSpread.IsZero() {
+		s.AskSpread = fixedpoint.NewFromFloat(0.0001)
+	}
+
+	s.session = session
+	s.Status = types.StrategyStatusRunning
+	s.stopC = make(chan struct{})
+
+	if s.Positions == nil {
+		s.Positions = make(map[string]*types.Position)
+	}
+	if s.ProfitStats == nil {
+		s.ProfitStats = make(map[string]*types.ProfitStats)
+	}
+	if s.TradeStats == nil {
+		s.TradeStats = make(map[string]*types.TradeStats)
+	}
+	if s.AccumulatedProfitReports == nil {
+		s.AccumulatedProfitReports = make(map[string]*AccumulatedProfitReport)
+	}
+
+	s.orderExecutors = make(map[string]*bbgo.GeneralOrderExecutor)
+	s.activeOrders = make(map[string]*bbgo.ActiveOrderBook)
+	s.nrrs = make(map[string]*NRR)
+	s.latestClose = make(map[string]fixedpoint.Value)
+	s.metrics = make(map[string]*metricsRecorder)
+	s.markets = make(map[string]types.Market)
+
+	if s.RiskTimezone == "" {
+		s.RiskTimezone = "UTC"
+	}
+	riskLocation, err := time.LoadLocation(s.RiskTimezone)
+	if err != nil {
+		log.WithError(err).Warnf("invalid riskTimezone %q, falling back to UTC", s.RiskTimezone)
+		riskLocation = time.UTC
+	}
+	s.riskManager = NewRiskManager(s.MaxDrawdown, s.DailyLossLimit, s.PositionSizeLimit, riskLocation)
+
+	if s.MetricsPort > 0 {
+		startMetricsServer(ctx, s.MetricsPort, s.stopC)
+	}
+
+	s.OnSuspend(func() {
+		for _, executor := range s.orderExecutors {
+			_ = executor.GracefulCancel(ctx)
+		}
+	})
+
+	s.OnEmergencyStop(func() {
+		for _, executor := range s.orderExecutors {
+			_ = executor.GracefulCancel(ctx)
+			_ = executor.ClosePosition(ctx, fixedpoint.One)
+		}
+	})
+
+	// For drawing: tracked across the whole portfolio, not per symbol, since
+	// the book is rebalanced as a single dollar-neutral unit.
+	portfolioProfitSlice := floats.Slice{1., 1.}
+	portfolioProfitDollarSlice := floats.Slice{0, 0}
+	portfolioCumProfitDollarSlice := floats.Slice{0, 0}
+	lastEquity := 0.0
+	portfolioCumProfitSlice := floats.Slice{0, 0}
+
+	for _, symbol := range s.Symbols {
+		market, ok := session.Market(symbol)
+		if !ok {
+			return fmt.Errorf("market not found: %s", symbol)
+		}
+		s.markets[symbol] = market
+		if s.quoteCurrency == "" {
+			s.quoteCurrency = market.QuoteCurrency
+		}
+
+		if s.Positions[symbol] == nil {
+			s.Positions[symbol] = types.NewPositionFromMarket(market)
+		}
+		if s.ProfitStats[symbol] == nil {
+			s.ProfitStats[symbol] = types.NewProfitStats(market)
+		}
+		if s.TradeStats[symbol] == nil {
+			s.TradeStats[symbol] = types.NewTradeStats(symbol)
+		}
+
+		if session.MakerFeeRate.Sign() > 0 || session.TakerFeeRate.Sign() > 0 {
+			s.Positions[symbol].SetExchangeFeeRate(session.ExchangeName, types.ExchangeFee{
+				MakerFeeRate: session.MakerFeeRate,
+				TakerFeeRate: session.TakerFeeRate,
+			})
+		}
+
+		executor := bbgo.NewGeneralOrderExecutor(session, symbol, CrossSectionalID, instanceID, s.Positions[symbol])
+		executor.BindEnvironment(s.Environment)
+		executor.BindProfitStats(s.ProfitStats[symbol])
+		executor.BindTradeStats(s.TradeStats[symbol])
+
+		if bbgo.IsBackTesting {
+			if s.AccumulatedProfitReports[symbol] == nil {
+				s.AccumulatedProfitReports[symbol] = &AccumulatedProfitReport{}
+			}
+			s.AccumulatedProfitReports[symbol].Initialize()
+
+			executor.TradeCollector().OnProfit(func(trade types.Trade, profit *types.Profit) {
+				if profit == nil {
+					return
+				}
+				s.AccumulatedProfitReports[symbol].RecordProfit(profit.Profit)
+			})
+		}
+
+		executor.TradeCollector().OnTrade(func(trade types.Trade, profit fixedpoint.Value, netProfit fixedpoint.Value) {
+			if bbgo.IsBackTesting {
+				s.AccumulatedProfitReports[symbol].RecordTrade(trade.Fee)
+				if m := s.metrics[symbol]; m != nil {
+					m.cumProfit.Set(s.AccumulatedProfitReports[symbol].accumulatedProfit.Float64())
+				}
+
+				// For drawing/charting the portfolio-level equity curve
+				portfolioProfitDollarSlice.Update(profit.Float64())
+				portfolioCumProfitDollarSlice.Update(portfolioProfitDollarSlice.Sum())
+
+				equity := s.calcAssetValue().Float64()
+				if lastEquity > 0 {
+					portfolioProfitSlice.Update(equity / lastEquity)
+				}
+				lastEquity = equity
+				portfolioCumProfitSlice.Update(equity)
+			}
+		})
+		executor.TradeCollector().OnPositionUpdate(func(position *types.Position) {
+			bbgo.Sync(ctx, s)
+		})
+		executor.Bind()
+
+		s.orderExecutors[symbol] = executor
+		s.activeOrders[symbol] = bbgo.NewActiveOrderBook(symbol)
+		s.metrics[symbol] = newMetricsRecorder(symbol, instanceID)
+
+		kLineStore, _ := session.MarketDataStore(symbol)
+		nrr := &NRR{IntervalWindow: types.IntervalWindow{Interval: s.Interval, Window: 2}, RankingWindow: s.Window, delay: true}
+		nrr.BindK(session.MarketDataStream, symbol, nrr.Interval)
+		if klines, ok := kLineStore.KLinesOfInterval(nrr.Interval); ok {
+			nrr.LoadK((*klines)[0:])
+		}
+		s.nrrs[symbol] = nrr
+
+		session.MarketDataStream.OnKLineClosed(types.KLineWith(symbol, s.Interval, func(kline types.KLine) {
+			s.latestClose[symbol] = kline.Close
+			s.onKLineClosed(ctx, kline)
+		}))
+
+		if bbgo.IsBackTesting {
+			session.MarketDataStream.OnKLineClosed(types.KLineWith(symbol, types.Interval1d, func(kline types.KLine) {
+				equity := s.calcAssetValue()
+				drawdown := s.riskManager.Drawdown(equity)
+				dailyPnl := s.riskManager.dailyPnL(equity)
+				exposure := s.Positions[symbol].Base.Mul(kline.Close).Abs()
+				s.AccumulatedProfitReports[symbol].DailyUpdate(symbol, kline.EndTime.Time(), s.TradeStats[symbol], drawdown, exposure)
+				if m := s.metrics[symbol]; m != nil {
+					m.drawdown.Set(drawdown.Float64())
+					m.dailyPnl.Set(dailyPnl.Float64())
+				}
+			}))
+		}
+	}
+
+	s.InitDrawCommands(&portfolioProfitSlice, &portfolioCumProfitSlice, &portfolioCumProfitDollarSlice)
+
+	bbgo.OnShutdown(ctx, func(ctx context.Context, wg *sync.WaitGroup) {
+		defer wg.Done()
+		if bbgo.IsBackTesting {
+			defer s.outputPortfolioReport()
+			if s.DrawGraph {
+				if err := s.Draw(&portfolioProfitSlice, &portfolioCumProfitSlice); err != nil {
+					log.WithError(err).Errorf("cannot draw graph")
+				}
+			}
+		} else {
+			close(s.stopC)
+		}
+
+		for symbol, executor := range s.orderExecutors {
+			_, _ = fmt.Fprintln(os.Stderr, symbol, s.TradeStats[symbol].String())
+			_ = executor.GracefulCancel(ctx)
+		}
+	})
+
+	return nil
+}
+
+// calcAssetValue sums the quote currency balance with every symbol's base
+// currency balance priced at its latest known close.
+func (s *CrossSectionalStrategy) calcAssetValue() fixedpoint.Value {
+	balances := s.session.GetAccount().Balances()
+	total := balances[s.quoteCurrency].Total()
+
+	for symbol, market := range s.markets {
+		price, ok := s.latestClose[symbol]
+		if !ok {
+			continue
+		}
+		total = total.Add(balances[market.BaseCurrency].Total().Mul(price))
+	}
+
+	return total
+}
+
+// onKLineClosed re-ranks the whole universe using the latest NRR alpha per
+// symbol and rebalances every symbol towards its new long/short/flat target.
+func (s *CrossSectionalStrategy) onKLineClosed(ctx context.Context, kline types.KLine) {
+	// Every symbol in the universe closes a kline on the same interval
+	// boundary, but the ranking/rebalance below looks at the whole
+	// portfolio, so only the first symbol to report a given bar should
+	// trigger it.
+	barTime := kline.StartTime.Time()
+	if !barTime.After(s.lastRebalanceBar) {
+		return
+	}
+	s.lastRebalanceBar = barTime
+
+	type scoredSymbol struct {
+		symbol string
+		value  float64
+	}
+
+	scores := make([]scoredSymbol, 0, len(s.Symbols))
+	for _, symbol := range s.Symbols {
+		nrr, ok := s.nrrs[symbol]
+		if !ok {
+			continue
+		}
+		value := nrr.RankedValues.Index(1)
+		scores = append(scores, scoredSymbol{symbol: symbol, value: value})
+		if m := s.metrics[symbol]; m != nil {
+			m.alphaNrr.Set(value)
+		}
+	}
+
+	sort.Slice(scores, func(i, j int) bool { return scores[i].value < scores[j].value })
+
+	k := s.TopK
+	if k > len(scores)/2 {
+		k = len(scores) / 2
+	}
+	if k <= 0 {
+		return
+	}
+
+	longs := make(map[string]bool, k)
+	shorts := make(map[string]bool, k)
+	// NRR is a negative return rate: the highest values are the worst-return
+	// names and the lowest values the best-return names.
+	for i := 0; i < k; i++ {
+		best := scores[i].symbol
+		worst := scores[len(scores)-1-i].symbol
+		if s.Reverse {
+			longs[best] = true
+			shorts[worst] = true
+		} else {
+			longs[worst] = true
+			shorts[best] = true
+		}
+	}
+
+	equity := s.calcAssetValue()
+	exposure := zero
+	for symbol, position := range s.Positions {
+		price, ok := s.latestClose[symbol]
+		if !ok {
+			continue
+		}
+		exposure = exposure.Add(position.Base.Mul(price).Abs())
+	}
+
+	if allowed, reason := s.riskManager.CheckAndGate(kline.EndTime.Time(), equity, exposure); !allowed {
+		log.Warnf("risk limit breached, skipping this tick: %s", reason)
+		return
+	}
+
+	names := fixedpoint.NewFromInt(int64(k))
+
+	// amountPerName is a dollar amount, shared by every leg; each leg's own
+	// base quantity is derived below from its own close, not the close of
+	// whichever symbol happened to trigger this bar, so every leg of the
+	// long/short book carries the same dollar notional. Run rejects
+	// Quantity-mode configs, so Amount is always set here.
+	amountPerName := s.QuantityOrAmount.Amount.Div(names)
+
+	for _, symbol := range s.Symbols {
+		price, ok := s.latestClose[symbol]
+		if !ok {
+			continue
+		}
+
+		perNameQty := amountPerName.Div(price)
+
+		var targetBase fixedpoint.Value
+		switch {
+		case longs[symbol]:
+			targetBase = perNameQty
+		case shorts[symbol]:
+			targetBase = perNameQty.Neg()
+		default:
+			targetBase = zero
+		}
+
+		s.rebalanceSymbol(ctx, symbol, targetBase, price)
+	}
+}
+
+// rebalanceSymbol cancels the symbol's open orders and submits a single
+// limit order to move its position towards targetBase.
+func (s *CrossSectionalStrategy) rebalanceSymbol(ctx context.Context, symbol string, targetBase, price fixedpoint.Value) {
+	executor, ok := s.orderExecutors[symbol]
+	if !ok {
+		return
+	}
+
+	market, ok := s.markets[symbol]
+	if !ok {
+		return
+	}
+
+	diffQty := targetBase.Sub(s.Positions[symbol].Base)
+	if s.metrics[symbol] != nil {
+		s.metrics[symbol].targetBase.Set(targetBase.Float64())
+		s.metrics[symbol].positionBase.Set(s.Positions[symbol].Base.Float64())
+		s.metrics[symbol].diffQty.Set(diffQty.Float64())
+	}
+
+	if diffQty.IsZero() {
+		return
+	}
+
+	orderQty := market.RoundDownQuantityByPrecision(diffQty.Abs())
+	if orderQty.Compare(market.MinQuantity) < 0 {
+		return
+	}
+
+	if err := executor.CancelOrders(ctx); err != nil {
+		log.WithError(err).Errorf("cancel order error for %s", symbol)
+	}
+
+	side := types.SideTypeBuy
+	orderPrice := price.Mul(one.Sub(s.BidSpread))
+	if diffQty.Sign() < 0 {
+		side = types.SideTypeSell
+		orderPrice = price.Mul(one.Add(s.AskSpread))
+	}
+
+	createdOrders, err := executor.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:   symbol,
+		Side:     side,
+		Type:     types.OrderTypeLimit,
+		Quantity: orderQty,
+		Price:    orderPrice,
+		Market:   market,
+	})
+	if err != nil {
+		log.WithError(err).Errorf("unable to submit order for %s", symbol)
+		if s.metrics[symbol] != nil {
+			s.metrics[symbol].ordersRejected.Inc()
+		}
+		return
+	}
+
+	if s.metrics[symbol] != nil {
+		s.metrics[symbol].ordersSubmitted.Add(float64(len(createdOrders)))
+	}
+
+	s.activeOrders[symbol].Add(createdOrders...)
+}
+
+// outputPortfolioReport writes each symbol's TSV report, closes its sink, and
+// logs the accumulated profit, fees and trade count summed across the whole
+// universe. The aggregate is only logged; no portfolio row is written to the
+// TSV files.
+func (s *CrossSectionalStrategy) outputPortfolioReport() {
+	portfolioProfit := zero
+	portfolioFee := zero
+	portfolioTrades := 0
+
+	for symbol, report := range s.AccumulatedProfitReports {
+		report.Output(symbol)
+		if err := report.Close(); err != nil {
+			log.WithError(err).Errorf("unable to close report sink for %s", symbol)
+		}
+
+		portfolioProfit = portfolioProfit.Add(report.accumulatedProfit)
+		portfolioFee = portfolioFee.Add(report.accumulatedFee)
+		portfolioTrades += report.accumulatedTrades
+	}
+
+	log.Infof("portfolio accumulated profit=%s fee=%s trades=%d", portfolioProfit.String(), portfolioFee.String(), portfolioTrades)
+}
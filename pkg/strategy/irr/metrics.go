@@ -0,0 +1,128 @@
+package irr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	metricAlphaNrr = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_alpha_nrr",
+		Help: "current ranked NRR alpha value",
+	}, []string{"symbol", "instance"})
+
+	metricPositionBase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_position_base",
+		Help: "current base currency position",
+	}, []string{"symbol", "instance"})
+
+	metricTargetBase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_target_base",
+		Help: "target base currency position",
+	}, []string{"symbol", "instance"})
+
+	metricDiffQty = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_diff_qty",
+		Help: "difference between target and current base position",
+	}, []string{"symbol", "instance"})
+
+	metricCumProfit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_cum_profit",
+		Help: "cumulative realized profit",
+	}, []string{"symbol", "instance"})
+
+	metricDailyPnl = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_daily_pnl",
+		Help: "profit and loss since today's anchor equity",
+	}, []string{"symbol", "instance"})
+
+	metricDrawdown = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "irr_drawdown",
+		Help: "current drawdown from the high-watermark equity",
+	}, []string{"symbol", "instance"})
+
+	metricOrdersSubmittedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "irr_orders_submitted_total",
+		Help: "total number of orders successfully submitted",
+	}, []string{"symbol", "instance"})
+
+	metricOrdersRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "irr_orders_rejected_total",
+		Help: "total number of orders rejected by SubmitOrders",
+	}, []string{"symbol", "instance"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricAlphaNrr,
+		metricPositionBase,
+		metricTargetBase,
+		metricDiffQty,
+		metricCumProfit,
+		metricDailyPnl,
+		metricDrawdown,
+		metricOrdersSubmittedTotal,
+		metricOrdersRejectedTotal,
+	)
+}
+
+// metricsRecorder curries the package's metric vectors down to a single
+// symbol/instance so call sites don't repeat label values on every update.
+type metricsRecorder struct {
+	alphaNrr        prometheus.Gauge
+	positionBase    prometheus.Gauge
+	targetBase      prometheus.Gauge
+	diffQty         prometheus.Gauge
+	cumProfit       prometheus.Gauge
+	dailyPnl        prometheus.Gauge
+	drawdown        prometheus.Gauge
+	ordersSubmitted prometheus.Counter
+	ordersRejected  prometheus.Counter
+}
+
+func newMetricsRecorder(symbol, instanceID string) *metricsRecorder {
+	labels := prometheus.Labels{"symbol": symbol, "instance": instanceID}
+	return &metricsRecorder{
+		alphaNrr:        metricAlphaNrr.With(labels),
+		positionBase:    metricPositionBase.With(labels),
+		targetBase:      metricTargetBase.With(labels),
+		diffQty:         metricDiffQty.With(labels),
+		cumProfit:       metricCumProfit.With(labels),
+		dailyPnl:        metricDailyPnl.With(labels),
+		drawdown:        metricDrawdown.With(labels),
+		ordersSubmitted: metricOrdersSubmittedTotal.With(labels),
+		ordersRejected:  metricOrdersRejectedTotal.With(labels),
+	}
+}
+
+// startMetricsServer starts an HTTP server exposing /metrics on port, and
+// shuts it down when ctx is cancelled or stopC is closed.
+func startMetricsServer(ctx context.Context, port int, stopC chan struct{}) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Errorf("metrics server error")
+		}
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopC:
+		}
+		_ = server.Shutdown(context.Background())
+	}()
+
+	return server
+}
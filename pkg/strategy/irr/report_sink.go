@@ -0,0 +1,139 @@
+package irr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	parquetSource "github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// DailyReportRecord is one row of the streaming daily report, emitted by
+// AccumulatedProfitReport.DailyUpdate through whichever ReportSink is
+// configured.
+type DailyReportRecord struct {
+	Symbol              string  `json:"symbol"`
+	Date                string  `json:"date"`
+	AccumulatedProfit   float64 `json:"accumulatedProfit"`
+	AccumulatedProfitMA float64 `json:"accumulatedProfitMA"`
+	IntervalProfit      float64 `json:"intervalProfit"`
+	AccumulatedFee      float64 `json:"accumulatedFee"`
+	WinRatio            float64 `json:"winRatio"`
+	ProfitFactor        float64 `json:"profitFactor"`
+	TradeCount          float64 `json:"tradeCount"`
+	Drawdown            float64 `json:"drawdown"`
+	Exposure            float64 `json:"exposure"`
+}
+
+// ReportSink receives one DailyReportRecord per daily update so that partial
+// backtest/live results survive a crash instead of being lost until shutdown.
+type ReportSink interface {
+	WriteDaily(record DailyReportRecord) error
+	Close() error
+}
+
+// jsonlReportSink appends one JSON object per line to a file.
+type jsonlReportSink struct {
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func newJSONLReportSink(path string) (*jsonlReportSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonlReportSink{file: f, encoder: json.NewEncoder(f)}, nil
+}
+
+func (s *jsonlReportSink) WriteDaily(record DailyReportRecord) error {
+	return s.encoder.Encode(record)
+}
+
+func (s *jsonlReportSink) Close() error {
+	return s.file.Close()
+}
+
+// parquetDailyRecord mirrors DailyReportRecord with parquet struct tags so
+// multiple backtest runs can be concatenated and queried with pandas/DuckDB.
+type parquetDailyRecord struct {
+	Symbol              string  `parquet:"name=symbol, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Date                string  `parquet:"name=date, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AccumulatedProfit   float64 `parquet:"name=accumulated_profit, type=DOUBLE"`
+	AccumulatedProfitMA float64 `parquet:"name=accumulated_profit_ma, type=DOUBLE"`
+	IntervalProfit      float64 `parquet:"name=interval_profit, type=DOUBLE"`
+	AccumulatedFee      float64 `parquet:"name=accumulated_fee, type=DOUBLE"`
+	WinRatio            float64 `parquet:"name=win_ratio, type=DOUBLE"`
+	ProfitFactor        float64 `parquet:"name=profit_factor, type=DOUBLE"`
+	TradeCount          float64 `parquet:"name=trade_count, type=DOUBLE"`
+	Drawdown            float64 `parquet:"name=drawdown, type=DOUBLE"`
+	Exposure            float64 `parquet:"name=exposure, type=DOUBLE"`
+}
+
+// parquetReportSink writes each daily record to its own complete parquet
+// file under dir. A single growing parquet file only gets a readable
+// footer once Close/WriteStop runs, so a crash mid-backtest would leave a
+// zero-byte or unparseable file; writing one self-contained file per
+// record instead means a crash only loses the record that hadn't been
+// written yet. Multiple runs' files can still be globbed together and
+// queried with pandas/DuckDB.
+type parquetReportSink struct {
+	dir string
+	seq int
+}
+
+func newParquetReportSink(dir string) (*parquetReportSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &parquetReportSink{dir: dir}, nil
+}
+
+func (s *parquetReportSink) WriteDaily(record DailyReportRecord) error {
+	s.seq++
+	path := filepath.Join(s.dir, fmt.Sprintf("%s_%s_%04d.parquet", record.Symbol, record.Date, s.seq))
+
+	fw, err := parquetSource.NewLocalFileWriter(path)
+	if err != nil {
+		return err
+	}
+
+	pw, err := writer.NewParquetWriter(fw, new(parquetDailyRecord), 1)
+	if err != nil {
+		_ = fw.Close()
+		return err
+	}
+
+	if err := pw.Write(parquetDailyRecord{
+		Symbol:              record.Symbol,
+		Date:                record.Date,
+		AccumulatedProfit:   record.AccumulatedProfit,
+		AccumulatedProfitMA: record.AccumulatedProfitMA,
+		IntervalProfit:      record.IntervalProfit,
+		AccumulatedFee:      record.AccumulatedFee,
+		WinRatio:            record.WinRatio,
+		ProfitFactor:        record.ProfitFactor,
+		TradeCount:          record.TradeCount,
+		Drawdown:            record.Drawdown,
+		Exposure:            record.Exposure,
+	}); err != nil {
+		_ = pw.WriteStop()
+		_ = fw.Close()
+		return err
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		_ = fw.Close()
+		return err
+	}
+
+	return fw.Close()
+}
+
+func (s *parquetReportSink) Close() error {
+	return nil
+}
@@ -0,0 +1,114 @@
+package irr
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+)
+
+// RiskManager enforces the Strategy's MaxDrawdown, DailyLossLimit and
+// PositionSizeLimit. It tracks an intraday high-watermark equity to derive
+// rolling drawdown, and resets its daily P&L anchor at midnight in Location.
+type RiskManager struct {
+	maxDrawdown       fixedpoint.Value
+	dailyLossLimit    fixedpoint.Value
+	positionSizeLimit fixedpoint.Value
+	location          *time.Location
+
+	mu                sync.Mutex
+	highWatermark     fixedpoint.Value
+	dailyAnchorEquity fixedpoint.Value
+	dailyAnchorDate   string
+}
+
+// NewRiskManager creates a RiskManager. A zero value for any of the limits
+// disables that particular check.
+func NewRiskManager(maxDrawdown, dailyLossLimit, positionSizeLimit fixedpoint.Value, location *time.Location) *RiskManager {
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &RiskManager{
+		maxDrawdown:       maxDrawdown,
+		dailyLossLimit:    dailyLossLimit,
+		positionSizeLimit: positionSizeLimit,
+		location:          location,
+	}
+}
+
+// update refreshes the high-watermark and, on a UTC (or configured timezone)
+// day rollover, re-anchors the daily P&L baseline to the given equity.
+func (r *RiskManager) update(now time.Time, equity fixedpoint.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.highWatermark.IsZero() || equity.Compare(r.highWatermark) > 0 {
+		r.highWatermark = equity
+	}
+
+	day := now.In(r.location).Format("2006-01-02")
+	if day != r.dailyAnchorDate {
+		r.dailyAnchorDate = day
+		r.dailyAnchorEquity = equity
+	}
+}
+
+// drawdown returns the current drawdown from the high-watermark equity, as a
+// ratio (e.g. 0.1 == 10%).
+func (r *RiskManager) drawdown(equity fixedpoint.Value) fixedpoint.Value {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.highWatermark.IsZero() {
+		return zero
+	}
+
+	return r.highWatermark.Sub(equity).Div(r.highWatermark)
+}
+
+// dailyPnL returns the change in equity since today's anchor.
+func (r *RiskManager) dailyPnL(equity fixedpoint.Value) fixedpoint.Value {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dailyAnchorEquity.IsZero() {
+		return zero
+	}
+
+	return equity.Sub(r.dailyAnchorEquity)
+}
+
+// Drawdown reports the current drawdown from the high-watermark equity
+// without updating the risk manager's state.
+func (r *RiskManager) Drawdown(equity fixedpoint.Value) fixedpoint.Value {
+	return r.drawdown(equity)
+}
+
+// CheckAndGate updates the risk manager with the latest equity/exposure
+// snapshot and reports whether trading should continue. When it returns
+// false, reason explains which limit was breached.
+func (r *RiskManager) CheckAndGate(now time.Time, equity, exposure fixedpoint.Value) (allowed bool, reason string) {
+	r.update(now, equity)
+
+	if r.maxDrawdown.Sign() > 0 {
+		if dd := r.drawdown(equity); dd.Compare(r.maxDrawdown) > 0 {
+			return false, fmt.Sprintf("drawdown %s exceeds maxDrawdown %s", dd.String(), r.maxDrawdown.String())
+		}
+	}
+
+	if r.dailyLossLimit.Sign() > 0 {
+		if pnl := r.dailyPnL(equity); pnl.Sign() < 0 && pnl.Neg().Compare(r.dailyLossLimit) > 0 {
+			return false, fmt.Sprintf("daily loss %s exceeds dailyLossLimit %s", pnl.Neg().String(), r.dailyLossLimit.String())
+		}
+	}
+
+	if r.positionSizeLimit.Sign() > 0 {
+		if exposure.Abs().Compare(r.positionSizeLimit) > 0 {
+			return false, fmt.Sprintf("exposure %s exceeds positionSizeLimit %s", exposure.Abs().String(), r.positionSizeLimit.String())
+		}
+	}
+
+	return true, ""
+}
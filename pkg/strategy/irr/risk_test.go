@@ -0,0 +1,71 @@
+package irr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+)
+
+func TestRiskManager_DrawdownLimit(t *testing.T) {
+	rm := NewRiskManager(fixedpoint.NewFromFloat(0.1), fixedpoint.Zero, fixedpoint.Zero, time.UTC)
+
+	day1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	allowed, reason := rm.CheckAndGate(day1, fixedpoint.NewFromFloat(1000), fixedpoint.Zero)
+	assert.True(t, allowed)
+	assert.Empty(t, reason)
+
+	// 5% drawdown, still under the 10% limit
+	allowed, reason = rm.CheckAndGate(day1.Add(time.Hour), fixedpoint.NewFromFloat(950), fixedpoint.Zero)
+	assert.True(t, allowed)
+	assert.Empty(t, reason)
+
+	// 12% drawdown breaches the 10% limit
+	allowed, reason = rm.CheckAndGate(day1.Add(2*time.Hour), fixedpoint.NewFromFloat(880), fixedpoint.Zero)
+	assert.False(t, allowed)
+	assert.Contains(t, reason, "drawdown")
+}
+
+func TestRiskManager_DailyLossLimitResetsAtMidnight(t *testing.T) {
+	rm := NewRiskManager(fixedpoint.Zero, fixedpoint.NewFromFloat(20), fixedpoint.Zero, time.UTC)
+
+	day1 := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	allowed, reason := rm.CheckAndGate(day1, fixedpoint.NewFromFloat(1000), fixedpoint.Zero)
+	assert.True(t, allowed)
+	assert.Empty(t, reason)
+
+	// down 30 from today's anchor, before the UTC day rolls over
+	allowed, reason = rm.CheckAndGate(day1.Add(time.Hour-time.Minute), fixedpoint.NewFromFloat(970), fixedpoint.Zero)
+	assert.False(t, allowed)
+	assert.Contains(t, reason, "daily loss")
+
+	// the day rolled over, so the anchor re-baselines and the same equity is fine
+	day2 := time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC)
+	allowed, reason = rm.CheckAndGate(day2, fixedpoint.NewFromFloat(970), fixedpoint.Zero)
+	assert.True(t, allowed)
+	assert.Empty(t, reason)
+}
+
+func TestRiskManager_PositionSizeLimit(t *testing.T) {
+	rm := NewRiskManager(fixedpoint.Zero, fixedpoint.Zero, fixedpoint.NewFromFloat(100), time.UTC)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	allowed, reason := rm.CheckAndGate(now, fixedpoint.NewFromFloat(1000), fixedpoint.NewFromFloat(50))
+	assert.True(t, allowed)
+	assert.Empty(t, reason)
+
+	allowed, reason = rm.CheckAndGate(now, fixedpoint.NewFromFloat(1000), fixedpoint.NewFromFloat(-150))
+	assert.False(t, allowed)
+	assert.Contains(t, reason, "exposure")
+}
+
+func TestRiskManager_ZeroLimitsDisableChecks(t *testing.T) {
+	rm := NewRiskManager(fixedpoint.Zero, fixedpoint.Zero, fixedpoint.Zero, nil)
+
+	allowed, reason := rm.CheckAndGate(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), fixedpoint.NewFromFloat(1), fixedpoint.NewFromFloat(1_000_000))
+	assert.True(t, allowed)
+	assert.Empty(t, reason)
+}
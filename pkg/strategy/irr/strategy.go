@@ -3,8 +3,10 @@ package irr
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/c9s/bbgo/pkg/bbgo"
 	"github.com/c9s/bbgo/pkg/data/tsv"
@@ -21,6 +23,7 @@ const ID = "irr"
 
 var one = fixedpoint.One
 var zero = fixedpoint.Zero
+var two = fixedpoint.NewFromInt(2)
 
 var log = logrus.WithField("strategy", ID)
 
@@ -87,6 +90,44 @@ type Strategy struct {
 	MaxDrawdown       fixedpoint.Value `json:"maxDrawdown"`       // 最大回撤限制
 	DailyLossLimit    fixedpoint.Value `json:"dailyLossLimit"`    // 每日亏损限制
 	PositionSizeLimit fixedpoint.Value `json:"positionSizeLimit"` // 最大仓位限制
+
+	// RiskTimezone is the timezone used to decide daily P&L resets, e.g. "UTC" or "America/New_York".
+	RiskTimezone string `json:"riskTimezone"`
+
+	riskManager *RiskManager
+
+	// ChunkSize is the number of slices a rebalance order is split into before submission.
+	ChunkSize int `json:"chunkSize"`
+
+	// ChunkInterval is the delay between chunk submissions, for TWAP-style execution.
+	// Zero (the default) submits all chunks back-to-back.
+	ChunkInterval types.Duration `json:"chunkInterval"`
+
+	// pendingChunkTimers holds the timers scheduled by placeOrders for chunks
+	// still waiting to fire, so a new rebalance can cancel the previous
+	// batch's tail instead of letting it fire on top of the new target.
+	pendingChunkMu     sync.Mutex
+	pendingChunkTimers []*time.Timer
+
+	// TrendWindow is the SMA window (in Interval bars) used to derive trend strength.
+	TrendWindow int `json:"trendWindow"`
+
+	// VolatilityWindow is the number of bar returns used to derive volatility.
+	VolatilityWindow int `json:"volatilityWindow"`
+
+	book *types.StreamBook
+
+	// trendSMA tracks the moving average of closing prices for calculateTrendStrength
+	trendSMA *indicator.SMA
+
+	// closeReturns tracks bar-to-bar returns for calculateVolatility
+	closeReturns floats.Slice
+	lastClose    float64
+
+	// MetricsPort, when set, starts a Prometheus /metrics HTTP server on that port.
+	MetricsPort int `json:"metricsPort"`
+
+	metrics *metricsRecorder
 }
 
 // AccumulatedProfitReport For accumulated profit report output
@@ -106,6 +147,17 @@ type AccumulatedProfitReport struct {
 	// AccumulatedDailyProfitWindow The window to sum up the daily profit, in days
 	AccumulatedDailyProfitWindow int `json:"accumulatedDailyProfitWindow"`
 
+	// ReportFormat selects the streaming daily ReportSink: "jsonl", "parquet",
+	// or "" (disabled, TSV-only via Output at shutdown)
+	ReportFormat string `json:"reportFormat"`
+
+	// ReportPath is the output location used by the ReportFormat sink: a
+	// single appended file for "jsonl", or a directory of one file per
+	// daily record for "parquet" (see parquetReportSink).
+	ReportPath string `json:"reportPath"`
+
+	sink ReportSink
+
 	// Accumulated profit
 	accumulatedProfit         fixedpoint.Value
 	accumulatedProfitPerDay   floats.Slice
@@ -148,6 +200,31 @@ func (r *AccumulatedProfitReport) Initialize() {
 		r.NumberOfInterval = 1
 	}
 	r.accumulatedProfitMA = &indicator.SMA{IntervalWindow: types.IntervalWindow{Interval: types.Interval1d, Window: r.AccumulatedProfitMAWindow}}
+
+	switch r.ReportFormat {
+	case "jsonl":
+		sink, err := newJSONLReportSink(r.ReportPath)
+		if err != nil {
+			log.WithError(err).Errorf("unable to open jsonl report sink %q", r.ReportPath)
+			break
+		}
+		r.sink = sink
+	case "parquet":
+		sink, err := newParquetReportSink(r.ReportPath)
+		if err != nil {
+			log.WithError(err).Errorf("unable to open parquet report sink %q", r.ReportPath)
+			break
+		}
+		r.sink = sink
+	}
+}
+
+// Close releases the resources held by the configured ReportSink, if any.
+func (r *AccumulatedProfitReport) Close() error {
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.Close()
 }
 
 func (r *AccumulatedProfitReport) RecordProfit(profit fixedpoint.Value) {
@@ -159,7 +236,10 @@ func (r *AccumulatedProfitReport) RecordTrade(fee fixedpoint.Value) {
 	r.accumulatedTrades += 1
 }
 
-func (r *AccumulatedProfitReport) DailyUpdate(tradeStats *types.TradeStats) {
+// DailyUpdate records the day's rolling statistics and, when a ReportSink is
+// configured, streams a DailyReportRecord so that partial results survive a
+// crash instead of only being written at shutdown.
+func (r *AccumulatedProfitReport) DailyUpdate(symbol string, date time.Time, tradeStats *types.TradeStats, drawdown, exposure fixedpoint.Value) {
 	// Daily profit
 	r.dailyProfit.Update(r.accumulatedProfit.Sub(r.previousAccumulatedProfit).Float64())
 	r.previousAccumulatedProfit = r.accumulatedProfit
@@ -183,6 +263,25 @@ func (r *AccumulatedProfitReport) DailyUpdate(tradeStats *types.TradeStats) {
 	// Daily trades
 	r.dailyTrades.Update(float64(r.accumulatedTrades - r.previousAccumulatedTrades))
 	r.previousAccumulatedTrades = r.accumulatedTrades
+
+	if r.sink != nil {
+		record := DailyReportRecord{
+			Symbol:              symbol,
+			Date:                date.Format("2006-01-02"),
+			AccumulatedProfit:   r.accumulatedProfit.Float64(),
+			AccumulatedProfitMA: r.accumulatedProfitMA.Last(0),
+			IntervalProfit:      r.dailyProfit.Tail(r.IntervalWindow).Sum(),
+			AccumulatedFee:      r.accumulatedFee.Float64(),
+			WinRatio:            tradeStats.WinningRatio.Float64(),
+			ProfitFactor:        tradeStats.ProfitFactor.Float64(),
+			TradeCount:          float64(r.accumulatedTrades),
+			Drawdown:            drawdown.Float64(),
+			Exposure:            exposure.Float64(),
+		}
+		if err := r.sink.WriteDaily(record); err != nil {
+			log.WithError(err).Errorf("unable to write daily report record")
+		}
+	}
 }
 
 // Output Accumulated profit report to a TSV file
@@ -294,7 +393,15 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 			s.AccumulatedProfitReport.RecordProfit(profit.Profit)
 		})
 		session.MarketDataStream.OnKLineClosed(types.KLineWith(s.Symbol, types.Interval1d, func(kline types.KLine) {
-			s.AccumulatedProfitReport.DailyUpdate(s.TradeStats)
+			equity := s.CalcAssetValue(kline.Close)
+			drawdown := s.riskManager.Drawdown(equity)
+			exposure := s.Position.Base.Mul(kline.Close).Abs()
+			s.AccumulatedProfitReport.DailyUpdate(s.Symbol, kline.EndTime.Time(), s.TradeStats, drawdown, exposure)
+
+			if s.metrics != nil {
+				s.metrics.drawdown.Set(drawdown.Float64())
+				s.metrics.dailyPnl.Set(s.riskManager.dailyPnL(equity).Float64())
+			}
 		}))
 	}
 
@@ -321,7 +428,20 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 			cumProfitSlice.Update(s.CalcAssetValue(trade.Price).Float64())
 		}
 		profitDollarSlice.Update(profit.Float64())
-		cumProfitDollarSlice.Update(profitDollarSlice.Sum())
+		cumProfit := profitDollarSlice.Sum()
+		cumProfitDollarSlice.Update(cumProfit)
+		if s.metrics != nil {
+			s.metrics.cumProfit.Set(cumProfit)
+		}
+		// wasLong/wasShort are used below so that a trailing stop's peak/trough
+		// is only reset on a flat->open transition, not on every fill: this
+		// strategy rebalances and fills on nearly every interval close, and
+		// checkStopPrice already ratchets highestPrice/lowestPrice forward
+		// from kline closes, so re-seeding them from the latest fill here
+		// would collapse the trailing stop back to a one-bar stop.
+		wasLong := s.buyPrice > 0
+		wasShort := s.sellPrice > 0
+
 		if s.Position.IsDust(trade.Price) {
 			s.buyPrice = 0
 			s.sellPrice = 0
@@ -330,13 +450,17 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 		} else if s.Position.IsLong() {
 			s.buyPrice = price
 			s.sellPrice = 0
-			s.highestPrice = s.buyPrice
+			if !wasLong {
+				s.highestPrice = price
+			}
 			s.lowestPrice = 0
 		} else {
 			s.sellPrice = price
 			s.buyPrice = 0
+			if !wasShort {
+				s.lowestPrice = price
+			}
 			s.highestPrice = 0
-			s.lowestPrice = s.sellPrice
 		}
 	})
 
@@ -348,6 +472,37 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 	s.orderExecutor.Bind()
 	s.activeOrders = bbgo.NewActiveOrderBook(s.Symbol)
 
+	// book is used by calculateDynamicSpread to read the live top-of-book
+	s.book = types.NewStreamBook(s.Symbol, session.ExchangeName)
+	s.book.BindStream(session.MarketDataStream)
+
+	if s.ChunkSize <= 0 {
+		s.ChunkSize = 3
+	}
+	if s.TrendWindow <= 0 {
+		s.TrendWindow = 20
+	}
+	if s.VolatilityWindow <= 0 {
+		s.VolatilityWindow = 20
+	}
+	s.trendSMA = &indicator.SMA{IntervalWindow: types.IntervalWindow{Interval: s.Interval, Window: s.TrendWindow}}
+
+	if s.RiskTimezone == "" {
+		s.RiskTimezone = "UTC"
+	}
+	riskLocation, err := time.LoadLocation(s.RiskTimezone)
+	if err != nil {
+		log.WithError(err).Warnf("invalid riskTimezone %q, falling back to UTC", s.RiskTimezone)
+		riskLocation = time.UTC
+	}
+	s.riskManager = NewRiskManager(s.MaxDrawdown, s.DailyLossLimit, s.PositionSizeLimit, riskLocation)
+
+	s.stopC = make(chan struct{})
+	if s.MetricsPort > 0 {
+		s.metrics = newMetricsRecorder(s.Symbol, instanceID)
+		startMetricsServer(ctx, s.MetricsPort, s.stopC)
+	}
+
 	kLineStore, _ := s.session.MarketDataStore(s.Symbol)
 	// window = 2 means day-to-day return, previousClose/currentClose -1
 	// delay = false means use open/close-1 as D0 return (default)
@@ -359,6 +514,24 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 	}
 
 	s.session.MarketDataStream.OnKLineClosed(types.KLineWith(s.Symbol, s.Interval, func(kline types.KLine) {
+		closePrice := kline.Close.Float64()
+		s.trendSMA.Update(closePrice)
+		if s.lastClose > 0 {
+			s.closeReturns.Update((closePrice - s.lastClose) / s.lastClose)
+		}
+		s.lastClose = closePrice
+
+		if !s.Position.IsDust(kline.Close) {
+			s.checkStopPrice(ctx, kline.Close)
+		}
+
+		equity := s.CalcAssetValue(kline.Close)
+		exposure := s.Position.Base.Mul(kline.Close).Abs()
+		if allowed, reason := s.riskManager.CheckAndGate(kline.EndTime.Time(), equity, exposure); !allowed {
+			log.Warnf("risk limit breached, skipping this tick: %s", reason)
+			return
+		}
+
 		alphaNrr := fixedpoint.NewFromFloat(s.nrr.RankedValues.Index(1))
 
 		// alpha-weighted inventory and cash
@@ -366,6 +539,13 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 		diffQty := targetBase.Sub(s.Position.Base)
 		log.Info(alphaNrr.Float64(), s.Position.Base, diffQty.Float64())
 
+		if s.metrics != nil {
+			s.metrics.alphaNrr.Set(alphaNrr.Float64())
+			s.metrics.positionBase.Set(s.Position.Base.Float64())
+			s.metrics.targetBase.Set(targetBase.Float64())
+			s.metrics.diffQty.Set(diffQty.Float64())
+		}
+
 		if err := s.orderExecutor.CancelOrders(ctx); err != nil {
 			log.WithError(err).Errorf("cancel order error")
 		}
@@ -391,6 +571,11 @@ func (s *Strategy) Run(ctx context.Context, orderExecutor bbgo.OrderExecutor, se
 		// Output accumulated profit report
 		if bbgo.IsBackTesting {
 			defer s.AccumulatedProfitReport.Output(s.Symbol)
+			defer func() {
+				if err := s.AccumulatedProfitReport.Close(); err != nil {
+					log.WithError(err).Errorf("unable to close report sink")
+				}
+			}()
 
 			if s.DrawGraph {
 				if err := s.Draw(&profitSlice, &cumProfitSlice); err != nil {
@@ -411,37 +596,203 @@ func (s *Strategy) CalcAssetValue(price fixedpoint.Value) fixedpoint.Value {
 	return balances[s.Market.BaseCurrency].Total().Mul(price).Add(balances[s.Market.QuoteCurrency].Total())
 }
 
+// placeOrders splits the rebalance quantity into chunks and submits them at a
+// price that is shifted away from the close by a dynamically computed spread.
 func (s *Strategy) placeOrders(ctx context.Context, diffQty fixedpoint.Value, kline types.KLine) {
+	// a new rebalance supersedes whatever chunks are still waiting from the
+	// previous one, so cancel their timers before scheduling fresh ones.
+	s.stopPendingChunks()
+
 	// 根据市场深度动态调整价差
-	orderBook := s.session.MarketDataStream.GetBook()
-	spread := calculateDynamicSpread(orderBook)
+	spread := s.calculateDynamicSpread()
 
-	if diffQty.Sign() > 0 {
+	var side types.SideType
+	var refPrice fixedpoint.Value
+	var chunks []fixedpoint.Value
+
+	switch {
+	case diffQty.Sign() > 0:
 		// 分批买入，避免冲击市场
-		chunks := splitOrderIntoChunks(diffQty, 3) // 将订单分成3份
-		for _, qty := range chunks {
-			bidPrice := kline.Close.Mul(fixedpoint.One.Sub(spread))
-			// ... 下单逻辑
-		}
-	} else if diffQty.Sign() < 0 {
+		side = types.SideTypeBuy
+		refPrice = kline.Close.Mul(one.Sub(spread))
+		chunks = s.splitOrderIntoChunks(diffQty, s.ChunkSize)
+	case diffQty.Sign() < 0:
 		// 分批卖出
-		chunks := splitOrderIntoChunks(diffQty.Abs(), 3)
-		for _, qty := range chunks {
-			askPrice := kline.Close.Mul(fixedpoint.One.Add(spread))
-			// ... 下单逻辑
+		side = types.SideTypeSell
+		refPrice = kline.Close.Mul(one.Add(spread))
+		chunks = s.splitOrderIntoChunks(diffQty.Abs(), s.ChunkSize)
+	default:
+		return
+	}
+
+	interval := s.ChunkInterval.Duration()
+	for i, qty := range chunks {
+		if qty.IsZero() {
+			continue
+		}
+
+		if i == 0 || interval <= 0 {
+			s.submitChunk(ctx, side, qty, refPrice)
+			continue
 		}
+
+		// TWAP-style spacing: schedule the later chunks on a timer instead of
+		// blocking the kline dispatch goroutine with time.Sleep.
+		delay := time.Duration(i) * interval
+		chunkQty := qty
+		timer := time.AfterFunc(delay, func() {
+			s.submitChunk(ctx, side, chunkQty, refPrice)
+		})
+
+		s.pendingChunkMu.Lock()
+		s.pendingChunkTimers = append(s.pendingChunkTimers, timer)
+		s.pendingChunkMu.Unlock()
 	}
 }
 
+// stopPendingChunks cancels any chunk timers still waiting to fire from a
+// previous placeOrders call.
+func (s *Strategy) stopPendingChunks() {
+	s.pendingChunkMu.Lock()
+	defer s.pendingChunkMu.Unlock()
+
+	for _, timer := range s.pendingChunkTimers {
+		timer.Stop()
+	}
+	s.pendingChunkTimers = nil
+}
+
+// submitChunk submits a single chunk of a split order and registers the
+// resulting orders with s.activeOrders.
+func (s *Strategy) submitChunk(ctx context.Context, side types.SideType, qty, price fixedpoint.Value) {
+	createdOrders, err := s.orderExecutor.SubmitOrders(ctx, types.SubmitOrder{
+		Symbol:   s.Symbol,
+		Side:     side,
+		Type:     types.OrderTypeLimit,
+		Quantity: qty,
+		Price:    price,
+		Market:   s.Market,
+	})
+	if err != nil {
+		log.WithError(err).Errorf("unable to submit order")
+		if s.metrics != nil {
+			s.metrics.ordersRejected.Inc()
+		}
+		return
+	}
+
+	if s.metrics != nil {
+		s.metrics.ordersSubmitted.Add(float64(len(createdOrders)))
+	}
+
+	s.activeOrders.Add(createdOrders...)
+}
+
+// splitOrderIntoChunks splits qty into n slices, each rounded down to the
+// market's volume precision and respecting MinQuantity. The last chunk absorbs the
+// rounding remainder so the sum of chunks never exceeds qty.
+func (s *Strategy) splitOrderIntoChunks(qty fixedpoint.Value, n int) []fixedpoint.Value {
+	if n <= 0 {
+		n = 1
+	}
+
+	chunkQty := s.Market.RoundDownQuantityByPrecision(qty.Div(fixedpoint.NewFromInt(int64(n))))
+	if chunkQty.Compare(s.Market.MinQuantity) < 0 {
+		chunkQty = s.Market.MinQuantity
+	}
+
+	var chunks []fixedpoint.Value
+	remaining := qty
+	for i := 0; i < n && remaining.Sign() > 0; i++ {
+		q := chunkQty
+		if i == n-1 || q.Compare(remaining) >= 0 {
+			q = remaining
+		}
+
+		if q.Compare(s.Market.MinQuantity) < 0 {
+			break
+		}
+
+		chunks = append(chunks, q)
+		remaining = remaining.Sub(q)
+	}
+
+	return chunks
+}
+
+// calculateDynamicSpread widens BidSpread/AskSpread's base spread using the
+// live order book: it reads the top-of-book depth and widens proportionally
+// to the bid/ask imbalance so that the strategy backs off when the book is
+// thin or one-sided.
+func (s *Strategy) calculateDynamicSpread() fixedpoint.Value {
+	baseSpread := s.BidSpread.Add(s.AskSpread).Div(two)
+
+	if s.book == nil {
+		return baseSpread
+	}
+
+	bid, ask, ok := s.book.BestBidAndAsk()
+	if !ok || bid.Price.IsZero() || ask.Price.IsZero() {
+		return baseSpread
+	}
+
+	depth := bid.Volume.Add(ask.Volume)
+	if depth.IsZero() {
+		return baseSpread
+	}
+
+	imbalance := bid.Volume.Sub(ask.Volume).Div(depth).Abs()
+
+	// depthFactor grows towards 1 as depth-at-touch shrinks towards zero, and
+	// towards 0 as the book gets deep, so a thin-but-balanced book still
+	// widens the spread instead of only reacting to imbalance.
+	depthFactor := one.Div(one.Add(depth))
+
+	mid := bid.Price.Add(ask.Price).Div(two)
+	bookSpread := ask.Price.Sub(bid.Price).Div(mid)
+
+	return baseSpread.Add(bookSpread).Mul(one.Add(imbalance).Add(depthFactor))
+}
+
+// calculateTrendStrength derives a position multiplier from the slope of the
+// close-price SMA over TrendWindow bars: a rising SMA scales the target
+// position up, a falling one scales it down.
+func (s *Strategy) calculateTrendStrength() float64 {
+	if s.trendSMA == nil || s.trendSMA.Length() < s.TrendWindow {
+		return 1.0
+	}
+
+	current := s.trendSMA.Last(0)
+	previous := s.trendSMA.Last(s.TrendWindow - 1)
+	if previous == 0 {
+		return 1.0
+	}
+
+	slope := (current - previous) / previous
+	return 1.0 + slope
+}
+
+// calculateVolatility derives a position multiplier from the stddev of bar
+// returns over VolatilityWindow bars, normalized so higher volatility shrinks
+// the target position.
+func (s *Strategy) calculateVolatility() float64 {
+	if len(s.closeReturns) < s.VolatilityWindow {
+		return 1.0
+	}
+
+	stddev := s.closeReturns.Tail(s.VolatilityWindow).Std()
+	return 1.0 / (1.0 + math.Abs(stddev))
+}
+
 func (s *Strategy) calculatePosition(kline types.KLine, alphaNrr fixedpoint.Value) fixedpoint.Value {
 	// 基础仓位
 	basePosition := s.QuantityOrAmount.CalculateQuantity(kline.Close)
 
 	// 根据趋势强度调整仓位
-	trendStrength := calculateTrendStrength() // 计算趋势强度
+	trendStrength := s.calculateTrendStrength() // 计算趋势强度
 
 	// 根据波动率调整仓位
-	volatility := calculateVolatility() // 计算波动率
+	volatility := s.calculateVolatility() // 计算波动率
 
 	// 动态调整最终仓位
 	targetBase := basePosition.Mul(alphaNrr).
@@ -451,35 +802,56 @@ func (s *Strategy) calculatePosition(kline types.KLine, alphaNrr fixedpoint.Valu
 	return targetBase
 }
 
+// checkStopPrice closes the position on stop-loss/take-profit, and when
+// TrailingStop is enabled, trails the stop using the highest price seen
+// while long (or lowest price seen while short).
 func (s *Strategy) checkStopPrice(ctx context.Context, currentPrice fixedpoint.Value) {
+	price := currentPrice.Float64()
+
 	if s.Position.IsLong() {
+		stopBasis := s.Position.AverageCost.Float64()
+		if s.TrailingStop {
+			if s.highestPrice == 0 || price > s.highestPrice {
+				s.highestPrice = price
+			}
+			stopBasis = s.highestPrice
+		}
+
 		// 止损价格
-		stopPrice := s.Position.AverageCost.Mul(fixedpoint.One.Sub(s.StopLoss))
-		if currentPrice.Compare(stopPrice) <= 0 {
+		stopPrice := stopBasis * (1 - s.StopLoss.Float64())
+		if price <= stopPrice {
 			_ = s.orderExecutor.ClosePosition(ctx, fixedpoint.One)
 			return
 		}
 
 		// 止盈价格
-		profitPrice := s.Position.AverageCost.Mul(fixedpoint.One.Add(s.TakeProfit))
-		if currentPrice.Compare(profitPrice) >= 0 {
+		profitPrice := s.Position.AverageCost.Float64() * (1 + s.TakeProfit.Float64())
+		if price >= profitPrice {
 			_ = s.orderExecutor.ClosePosition(ctx, fixedpoint.One)
-			return
 		}
+		return
 	}
-	// 做空方向类似...
-}
 
-func (s *Strategy) checkRiskLimits() bool {
-	// 检查回撤
-	if s.calculateDrawdown() > s.MaxDrawdown {
-		return false
-	}
+	if s.Position.IsShort() {
+		stopBasis := s.Position.AverageCost.Float64()
+		if s.TrailingStop {
+			if s.lowestPrice == 0 || price < s.lowestPrice {
+				s.lowestPrice = price
+			}
+			stopBasis = s.lowestPrice
+		}
 
-	// 检查每日亏损
-	if s.calculateDailyPnL() < s.DailyLossLimit.Neg() {
-		return false
-	}
+		// 止损价格
+		stopPrice := stopBasis * (1 + s.StopLoss.Float64())
+		if price >= stopPrice {
+			_ = s.orderExecutor.ClosePosition(ctx, fixedpoint.One)
+			return
+		}
 
-	return true
+		// 止盈价格
+		profitPrice := s.Position.AverageCost.Float64() * (1 - s.TakeProfit.Float64())
+		if price <= profitPrice {
+			_ = s.orderExecutor.ClosePosition(ctx, fixedpoint.One)
+		}
+	}
 }
@@ -0,0 +1,88 @@
+package irr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/c9s/bbgo/pkg/fixedpoint"
+	"github.com/c9s/bbgo/pkg/indicator"
+	"github.com/c9s/bbgo/pkg/types"
+)
+
+func newTestMarket() types.Market {
+	return types.Market{
+		Symbol:          "BTCUSDT",
+		BaseCurrency:    "BTC",
+		QuoteCurrency:   "USDT",
+		VolumePrecision: 4,
+		PricePrecision:  2,
+		StepSize:        fixedpoint.NewFromFloat(0.0001),
+		MinQuantity:     fixedpoint.NewFromFloat(0.001),
+	}
+}
+
+func TestStrategy_splitOrderIntoChunks(t *testing.T) {
+	s := &Strategy{Market: newTestMarket()}
+
+	chunks := s.splitOrderIntoChunks(fixedpoint.NewFromFloat(1.0), 3)
+	assert.NotEmpty(t, chunks)
+
+	sum := zero
+	for _, c := range chunks {
+		assert.True(t, c.Compare(s.Market.MinQuantity) >= 0, "chunk %s below MinQuantity", c.String())
+		sum = sum.Add(c)
+	}
+	assert.True(t, sum.Compare(fixedpoint.NewFromFloat(1.0)) <= 0, "chunks must not exceed the requested quantity")
+}
+
+func TestStrategy_splitOrderIntoChunks_BelowMinQuantity(t *testing.T) {
+	s := &Strategy{Market: newTestMarket()}
+
+	chunks := s.splitOrderIntoChunks(fixedpoint.NewFromFloat(0.0005), 3)
+	assert.Empty(t, chunks, "a quantity below MinQuantity should produce no chunks")
+}
+
+func TestStrategy_splitOrderIntoChunks_DefaultsToOneChunk(t *testing.T) {
+	s := &Strategy{Market: newTestMarket()}
+
+	chunks := s.splitOrderIntoChunks(fixedpoint.NewFromFloat(1.0), 0)
+	assert.Len(t, chunks, 1)
+}
+
+func TestStrategy_calculateTrendStrength(t *testing.T) {
+	s := &Strategy{TrendWindow: 3}
+	s.trendSMA = &indicator.SMA{IntervalWindow: types.IntervalWindow{Window: 3}}
+
+	// not enough data points yet => neutral multiplier
+	assert.Equal(t, 1.0, s.calculateTrendStrength())
+
+	for _, v := range []float64{100, 101, 102, 103, 104} {
+		s.trendSMA.Update(v)
+	}
+
+	assert.Greater(t, s.calculateTrendStrength(), 1.0)
+}
+
+func TestStrategy_calculateVolatility(t *testing.T) {
+	s := &Strategy{VolatilityWindow: 3}
+
+	// not enough returns yet => neutral multiplier
+	assert.Equal(t, 1.0, s.calculateVolatility())
+
+	for _, r := range []float64{0.01, -0.01, 0.02, -0.02} {
+		s.closeReturns.Update(r)
+	}
+
+	assert.Less(t, s.calculateVolatility(), 1.0)
+}
+
+func TestStrategy_calculateDynamicSpread_NoBook(t *testing.T) {
+	s := &Strategy{
+		BidSpread: fixedpoint.NewFromFloat(0.001),
+		AskSpread: fixedpoint.NewFromFloat(0.002),
+	}
+
+	spread := s.calculateDynamicSpread()
+	assert.Equal(t, s.BidSpread.Add(s.AskSpread).Div(two), spread)
+}